@@ -0,0 +1,65 @@
+// Package checkpoint stores and restores CRIU checkpoints of function tasks
+// in the containerd content store, so a scale-from-zero deploy can resume a
+// running process instead of paying the fprocess startup cost again.
+package checkpoint
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/errdefs"
+)
+
+// DisabledLabel opts a function out of checkpoint/restore even when a
+// checkpoint image exists for it.
+const DisabledLabel = "com.openfaas.checkpoint.disabled"
+
+// imageRef returns the content store tag a function's checkpoint is stored
+// under.
+func imageRef(service string) string {
+	return fmt.Sprintf("%s:checkpoint", service)
+}
+
+// Disabled reports whether the function's labels opt out of checkpointing.
+func Disabled(labels map[string]string) bool {
+	return labels[DisabledLabel] == "true"
+}
+
+// Take checkpoints the running task of container and stores the resulting
+// image in the local content store keyed by <service>:checkpoint, replacing
+// any checkpoint already stored for that service.
+func Take(ctx context.Context, client *containerd.Client, container containerd.Container, service string) (containerd.Image, error) {
+	if _, err := container.Task(ctx, nil); err != nil {
+		return nil, fmt.Errorf("unable to find running task for %s: %s", container.ID(), err)
+	}
+
+	if err := Delete(ctx, client, service); err != nil {
+		return nil, fmt.Errorf("unable to remove previous checkpoint for %s: %s", service, err)
+	}
+
+	image, err := container.Checkpoint(ctx, imageRef(service), containerd.WithCheckpointTask)
+	if err != nil {
+		return nil, fmt.Errorf("unable to checkpoint %s: %s", service, err)
+	}
+
+	return image, nil
+}
+
+// Lookup returns the stored checkpoint image for service, if one exists.
+func Lookup(ctx context.Context, client *containerd.Client, service string) (containerd.Image, bool) {
+	image, err := client.GetImage(ctx, imageRef(service))
+	if err != nil {
+		return nil, false
+	}
+	return image, true
+}
+
+// Delete removes the stored checkpoint image for service, if any.
+func Delete(ctx context.Context, client *containerd.Client, service string) error {
+	err := client.ImageService().Delete(ctx, imageRef(service))
+	if err != nil && !errdefs.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package checkpoint
+
+import "testing"
+
+func Test_Disabled(t *testing.T) {
+	if Disabled(map[string]string{}) {
+		t.Errorf("want: false for no labels, got: true")
+	}
+
+	if !Disabled(map[string]string{DisabledLabel: "true"}) {
+		t.Errorf("want: true, got: false")
+	}
+}
+
+func Test_ImageRef(t *testing.T) {
+	if got, want := imageRef("echo"), "echo:checkpoint"; got != want {
+		t.Errorf("want: %s, got: %s", want, got)
+	}
+}
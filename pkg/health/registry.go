@@ -0,0 +1,136 @@
+// Package health probes running function containers over HTTP and reports
+// back when one has gone unresponsive, so the provider can recreate its task.
+package health
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxConsecutiveFailures is how many failed probes in a row mark a container
+// unhealthy.
+const maxConsecutiveFailures = 3
+
+// Registry tracks one probe goroutine per container ID, keyed so that it can
+// be rebuilt after a faasd restart by walking existing containers.
+type Registry struct {
+	mu     sync.Mutex
+	cancel map[string]context.CancelFunc
+}
+
+// NewRegistry returns an empty health check registry.
+func NewRegistry() *Registry {
+	return &Registry{cancel: map[string]context.CancelFunc{}}
+}
+
+// Start begins probing containerID at ip:8080/path on the given interval.
+// onHealthy, if non-nil, fires once on the first successful probe -- the
+// earliest proof the function is actually serving, used to trigger an
+// automatic checkpoint. After maxConsecutiveFailures in a row, onUnhealthy
+// is invoked and the probe stops; callers are expected to recreate the task
+// and call Start again.
+func (r *Registry) Start(containerID string, ip net.IP, path string, interval, timeout time.Duration, onHealthy, onUnhealthy func()) {
+	r.Stop(containerID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r.mu.Lock()
+	r.cancel[containerID] = cancel
+	r.mu.Unlock()
+
+	go r.probe(ctx, containerID, ip, path, interval, timeout, onHealthy, onUnhealthy)
+}
+
+// Stop cancels any in-flight probe for containerID. It is a no-op if none is
+// running.
+func (r *Registry) Stop(containerID string) {
+	r.mu.Lock()
+	cancel, ok := r.cancel[containerID]
+	delete(r.cancel, containerID)
+	r.mu.Unlock()
+
+	if ok {
+		cancel()
+	}
+}
+
+func (r *Registry) probe(ctx context.Context, containerID string, ip net.IP, path string, interval, timeout time.Duration, onHealthy, onUnhealthy func()) {
+	httpClient := &http.Client{Timeout: timeout}
+	url := fmt.Sprintf("http://%s:8080/%s", ip.String(), strings.TrimPrefix(path, "/"))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	failures := 0
+	seenHealthy := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if probeOnce(httpClient, url) {
+				failures = 0
+
+				if !seenHealthy {
+					seenHealthy = true
+					if onHealthy != nil {
+						onHealthy()
+					}
+				}
+				continue
+			}
+
+			failures++
+			if failures >= maxConsecutiveFailures {
+				r.Stop(containerID)
+				onUnhealthy()
+				return
+			}
+		}
+	}
+}
+
+func probeOnce(client *http.Client, url string) bool {
+	resp, err := client.Get(url)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode < 400
+}
+
+// readinessPollInterval is how often WaitForReady retries a failing probe.
+const readinessPollInterval = 500 * time.Millisecond
+
+// WaitForReady polls ip:8080/path until it responds successfully or deadline
+// elapses, returning an error in the latter case. It is used by the update
+// handler to gate a blue/green swap on the replacement task actually being
+// up before traffic is sent to it.
+func WaitForReady(ip net.IP, path string, timeout, deadline time.Duration) error {
+	httpClient := &http.Client{Timeout: timeout}
+	url := fmt.Sprintf("http://%s:8080/%s", ip.String(), strings.TrimPrefix(path, "/"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), deadline)
+	defer cancel()
+
+	ticker := time.NewTicker(readinessPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if probeOnce(httpClient, url) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for %s to become ready", url)
+		case <-ticker.C:
+		}
+	}
+}
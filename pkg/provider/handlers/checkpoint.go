@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/namespaces"
+	gocni "github.com/containerd/go-cni"
+	faasd "github.com/openfaas/faasd/pkg"
+	"github.com/openfaas/faasd/pkg/checkpoint"
+)
+
+// MakeCheckpointHandler returns a handler that takes a CRIU checkpoint of a
+// named function's running task on demand, so a later deploy can restore
+// from it instead of starting the fprocess from scratch. Functions already
+// get one automatically the first time they respond successfully (see
+// checkpointOnFirstSuccess and checkpointAfterFirstInvocation); this endpoint
+// takes one on demand, e.g. to refresh it after the process has warmed up
+// further.
+func MakeCheckpointHandler(client *containerd.Client) func(w http.ResponseWriter, r *http.Request) {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			http.Error(w, "expected a name query parameter", http.StatusBadRequest)
+			return
+		}
+
+		ctx := namespaces.WithNamespace(context.Background(), faasd.FunctionNamespace)
+
+		container, err := client.LoadContainer(ctx, currentContainerID(name))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		labels, err := container.Labels(ctx)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if checkpoint.Disabled(labels) {
+			http.Error(w, "checkpointing is disabled for "+name, http.StatusBadRequest)
+			return
+		}
+
+		if _, err := checkpoint.Take(ctx, client, container, name); err != nil {
+			log.Printf("[Checkpoint] error checkpointing %s: %s\n", name, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		log.Printf("[Checkpoint] stored checkpoint for %s\n", name)
+	}
+}
+
+// MakeRestoreHandler returns a handler that recreates a named function's
+// task from its stored checkpoint, if one exists, falling back to a fresh
+// task otherwise.
+func MakeRestoreHandler(client *containerd.Client, cni gocni.CNI) func(w http.ResponseWriter, r *http.Request) {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			http.Error(w, "expected a name query parameter", http.StatusBadRequest)
+			return
+		}
+
+		ctx := namespaces.WithNamespace(context.Background(), faasd.FunctionNamespace)
+
+		container, err := client.LoadContainer(ctx, currentContainerID(name))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		if err := createTask(ctx, client, container, cni); err != nil {
+			log.Printf("[Restore] error restoring %s: %s\n", name, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		log.Printf("[Restore] restored %s\n", name)
+	}
+}
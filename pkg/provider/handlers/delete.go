@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/namespaces"
+	"github.com/openfaas/faas-provider/types"
+	faasd "github.com/openfaas/faasd/pkg"
+	"github.com/openfaas/faasd/pkg/checkpoint"
+)
+
+// MakeDeleteHandler returns a handler that tears down a function's container
+// and task, along with any checkpoint image stored for it.
+func MakeDeleteHandler(client *containerd.Client) func(w http.ResponseWriter, r *http.Request) {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		if r.Body == nil {
+			http.Error(w, "expected a body", http.StatusBadRequest)
+			return
+		}
+
+		defer r.Body.Close()
+
+		body, _ := ioutil.ReadAll(r.Body)
+
+		req := types.DeleteFunctionRequest{}
+		if err := json.Unmarshal(body, &req); err != nil {
+			log.Printf("[Delete] - error parsing input: %s\n", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		name := req.FunctionName
+		ctx := namespaces.WithNamespace(context.Background(), faasd.FunctionNamespace)
+
+		container, err := client.LoadContainer(ctx, currentContainerID(name))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		if err := removeContainer(ctx, container); err != nil {
+			log.Printf("[Delete] error removing %s: %s\n", name, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		deleteCurrentContainerID(name)
+
+		if err := checkpoint.Delete(ctx, client, name); err != nil {
+			log.Printf("[Delete] error removing checkpoint for %s: %s\n", name, err)
+		}
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/openfaas/faas-provider/types"
 	faasd "github.com/openfaas/faasd/pkg"
+	"github.com/openfaas/faasd/pkg/checkpoint"
 	cninetwork "github.com/openfaas/faasd/pkg/cninetwork"
 	"github.com/openfaas/faasd/pkg/service"
 	"github.com/pkg/errors"
@@ -78,7 +79,12 @@ func deploy(ctx context.Context, req types.FunctionDeployment, client *container
 		snapshotter = val
 	}
 
-	image, err := service.PrepareImage(ctx, client, imgRef, snapshotter, alwaysPull)
+	resolver, err := resolverForImage(secretMountPath, r)
+	if err != nil {
+		return errors.Wrapf(err, "unable to resolve registry credentials for %s", imgRef)
+	}
+
+	image, err := service.PrepareImage(ctx, client, imgRef, snapshotter, alwaysPull, resolver)
 	if err != nil {
 		return errors.Wrapf(err, "unable to pull image %s", imgRef)
 	}
@@ -89,6 +95,10 @@ func deploy(ctx context.Context, req types.FunctionDeployment, client *container
 	envs := prepareEnv(req.EnvProcess, req.EnvVars)
 	mounts := getMounts()
 
+	if readOnlyRootFSEnabled(req.Annotations) {
+		mounts = append(mounts, tmpfsMounts(req.Annotations)...)
+	}
+
 	for _, secret := range req.Secrets {
 		mounts = append(mounts, specs.Mount{
 			Destination: path.Join("/var/openfaas/secrets", secret),
@@ -101,17 +111,34 @@ func deploy(ctx context.Context, req types.FunctionDeployment, client *container
 	name := req.Service
 
 	labels, err := buildLabels(&req)
-	
+	if err != nil {
+		return fmt.Errorf("unable to build labels for %s: %s", name, err)
+	}
+
+	resourceOpts, err := buildResourceSpecOpts(req.Limits, req.Requests)
+	if err != nil {
+		return fmt.Errorf("unable to apply resources for %s: %s", name, err)
+	}
+
+	specOpts := []oci.SpecOpts{
+		oci.WithImageConfig(image),
+		oci.WithCapabilities([]string{"CAP_NET_RAW"}),
+		oci.WithMounts(mounts),
+		oci.WithEnv(envs),
+	}
+	specOpts = append(specOpts, resourceOpts...)
+
+	if readOnlyRootFSEnabled(req.Annotations) {
+		specOpts = append(specOpts, oci.WithRootFSReadonly())
+	}
+
 	container, err := client.NewContainer(
 		ctx,
 		name,
 		containerd.WithImage(image),
 		containerd.WithSnapshotter(snapshotter),
 		containerd.WithNewSnapshot(name+"-snapshot", image),
-		containerd.WithNewSpec(oci.WithImageConfig(image),
-			oci.WithCapabilities([]string{"CAP_NET_RAW"}),
-			oci.WithMounts(mounts),
-			oci.WithEnv(envs)),
+		containerd.WithNewSpec(specOpts...),
 		containerd.WithContainerLabels(labels),
 	)
 
@@ -144,6 +171,16 @@ func buildLabels(request *types.FunctionDeployment) (map[string]string, error) {
 		}
 	}
 
+	if request.Limits != nil {
+		labels[resourceLabelLimitsMemory] = request.Limits.Memory
+		labels[resourceLabelLimitsCPU] = request.Limits.CPU
+	}
+
+	if request.Requests != nil {
+		labels[resourceLabelRequestsMemory] = request.Requests.Memory
+		labels[resourceLabelRequestsCPU] = request.Requests.CPU
+	}
+
 	//log.Printf("Built %d labels in total", len(labels))
 	return labels, nil
 }
@@ -153,7 +190,20 @@ func createTask(ctx context.Context, client *containerd.Client, container contai
 	name := container.ID()
 	// task, taskErr := container.NewTask(ctx, cio.NewCreator(cio.WithStdio))
 
-	task, taskErr := container.NewTask(ctx, cio.BinaryIO("/usr/local/bin/faasd", nil))
+	containerLabels, err := container.Labels(ctx)
+	if err != nil {
+		return err
+	}
+
+	taskOpts := []containerd.NewTaskOpts{}
+	if !checkpoint.Disabled(containerLabels) {
+		if image, ok := checkpoint.Lookup(ctx, client, canonicalServiceName(name)); ok {
+			log.Printf("Restoring %s from checkpoint\n", name)
+			taskOpts = append(taskOpts, containerd.WithTaskCheckpoint(image))
+		}
+	}
+
+	task, taskErr := container.NewTask(ctx, cio.BinaryIO("/usr/local/bin/faasd", nil), taskOpts...)
 
 	if taskErr != nil {
 		return fmt.Errorf("unable to start task: %s, error: %s", name, taskErr)
@@ -174,6 +224,8 @@ func createTask(ctx context.Context, client *containerd.Client, container contai
 	}
 	log.Printf("%s has IP: %s.\n", name, ip.String())
 
+	setFunctionIP(name, ip)
+
 	_, waitErr := task.Wait(ctx)
 	if waitErr != nil {
 		return errors.Wrapf(waitErr, "Unable to wait for task to start: %s", name)
@@ -182,6 +234,9 @@ func createTask(ctx context.Context, client *containerd.Client, container contai
 	if startErr := task.Start(ctx); startErr != nil {
 		return errors.Wrapf(startErr, "Unable to start task: %s", name)
 	}
+
+	startHealthCheck(ctx, client, cni, container, ip, containerLabels)
+
 	return nil
 }
 
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/namespaces"
+	gocni "github.com/containerd/go-cni"
+	faasd "github.com/openfaas/faasd/pkg"
+	"github.com/openfaas/faasd/pkg/checkpoint"
+	cninetwork "github.com/openfaas/faasd/pkg/cninetwork"
+	"github.com/openfaas/faasd/pkg/health"
+)
+
+const (
+	healthPathAnnotation     = "com.openfaas.health.http.path"
+	healthIntervalAnnotation = "com.openfaas.health.interval"
+	healthTimeoutAnnotation  = "com.openfaas.health.timeout"
+
+	defaultHealthInterval = 5 * time.Second
+	defaultHealthTimeout  = 3 * time.Second
+)
+
+// healthRegistry tracks in-flight health probes for every running function
+// task, keyed by container ID.
+var healthRegistry = health.NewRegistry()
+
+// healthConfigFromLabels reads the health check annotations populated by
+// buildLabels. ok is false when no health check path was configured.
+func healthConfigFromLabels(labels map[string]string) (path string, interval, timeout time.Duration, ok bool) {
+	path = labels[annotationLabelPrefix+healthPathAnnotation]
+	if path == "" {
+		return "", 0, 0, false
+	}
+
+	interval = defaultHealthInterval
+	if val, err := strconv.Atoi(labels[annotationLabelPrefix+healthIntervalAnnotation]); err == nil {
+		interval = time.Duration(val) * time.Second
+	}
+
+	timeout = defaultHealthTimeout
+	if val, err := strconv.Atoi(labels[annotationLabelPrefix+healthTimeoutAnnotation]); err == nil {
+		timeout = time.Duration(val) * time.Second
+	}
+
+	return path, interval, timeout, true
+}
+
+// checkpointOnFirstSuccess returns an onHealthy callback that takes a
+// checkpoint of container the first time it passes its health probe -- the
+// earliest point a scale-from-zero restore can safely resume from. container
+// may be either the "<svc>" or "<svc>-next" slot; the checkpoint is always
+// stored under the logical service name so a later deploy finds it
+// regardless of which slot is live by then. It is a no-op for functions that
+// opted out via checkpoint.DisabledLabel.
+func checkpointOnFirstSuccess(client *containerd.Client, container containerd.Container, labels map[string]string) func() {
+	if checkpoint.Disabled(labels) {
+		return nil
+	}
+
+	service := canonicalServiceName(container.ID())
+
+	return func() {
+		checkpointCtx := namespaces.WithNamespace(context.Background(), faasd.FunctionNamespace)
+		if _, err := checkpoint.Take(checkpointCtx, client, container, service); err != nil {
+			log.Printf("[Health] unable to checkpoint %s after first successful probe: %s\n", service, err)
+		}
+	}
+}
+
+// checkpointAfterFirstInvocation takes a one-shot checkpoint of container the
+// first time it responds successfully, for functions that have no health
+// check annotation configured and so never go through the repeating probe in
+// checkpointOnFirstSuccess. There is no request-interception point in the
+// provider to hook a literal "first invocation" event, so a single readiness
+// probe against the same default path/timeout used by the update handler's
+// own readiness gate stands in for it.
+func checkpointAfterFirstInvocation(client *containerd.Client, container containerd.Container, labels map[string]string, ip net.IP) {
+	if checkpoint.Disabled(labels) {
+		return
+	}
+
+	service := canonicalServiceName(container.ID())
+
+	go func() {
+		if err := health.WaitForReady(ip, defaultReadinessPath, defaultReadinessProbe, readinessDeadline); err != nil {
+			log.Printf("[Health] %s never became ready for its initial checkpoint: %s\n", service, err)
+			return
+		}
+
+		checkpointCtx := namespaces.WithNamespace(context.Background(), faasd.FunctionNamespace)
+		if _, err := checkpoint.Take(checkpointCtx, client, container, service); err != nil {
+			log.Printf("[Health] unable to checkpoint %s after first successful invocation: %s\n", service, err)
+		}
+	}()
+}
+
+// startHealthCheck configures a health probe for a freshly created task, if
+// the function opted in via the health annotations. On repeated failure the
+// task is killed and recreated through createTask. The first successful
+// probe (or, lacking a health check, the first successful invocation-style
+// readiness probe) triggers an automatic checkpoint, so the next
+// scale-from-zero deploy can restore straight from it instead of paying a
+// cold fprocess start.
+func startHealthCheck(ctx context.Context, client *containerd.Client, cni gocni.CNI, container containerd.Container, ip net.IP, labels map[string]string) {
+	path, interval, timeout, ok := healthConfigFromLabels(labels)
+	if !ok {
+		checkpointAfterFirstInvocation(client, container, labels, ip)
+		return
+	}
+
+	name := container.ID()
+	onHealthy := checkpointOnFirstSuccess(client, container, labels)
+	healthRegistry.Start(name, ip, path, interval, timeout, onHealthy, func() {
+		log.Printf("[Health] %s failed %s, recreating task\n", name, path)
+
+		restartCtx := namespaces.WithNamespace(context.Background(), faasd.FunctionNamespace)
+
+		task, err := container.Task(restartCtx, nil)
+		if err == nil {
+			teardownCNINetwork(restartCtx, cni, task, name)
+
+			if _, err := task.Delete(restartCtx, containerd.WithProcessKill); err != nil {
+				log.Printf("[Health] unable to kill unhealthy task %s: %s\n", name, err)
+			}
+		}
+
+		if err := createTask(restartCtx, client, container, cni); err != nil {
+			log.Printf("[Health] unable to recreate task for %s: %s\n", name, err)
+		}
+	})
+}
+
+// teardownCNINetwork releases the CNI endpoint allocated for task, so that a
+// subsequent createTask for the same container ID doesn't leak or collide
+// with the previous allocation.
+func teardownCNINetwork(ctx context.Context, cni gocni.CNI, task containerd.Task, name string) {
+	if err := cninetwork.DeleteCNINetwork(ctx, cni, task); err != nil {
+		log.Printf("[Health] unable to remove CNI network for %s: %s\n", name, err)
+	}
+}
+
+// RestoreHealthChecks re-attaches health probes after a faasd restart by
+// walking the existing containers in the openfaas-fn namespace.
+func RestoreHealthChecks(ctx context.Context, client *containerd.Client, cni gocni.CNI) error {
+	ctx = namespaces.WithNamespace(ctx, faasd.FunctionNamespace)
+
+	containers, err := client.Containers(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to list containers: %s", err)
+	}
+
+	for _, c := range containers {
+		labels, err := c.Labels(ctx)
+		if err != nil {
+			log.Printf("[Health] unable to read labels for %s: %s\n", c.ID(), err)
+			continue
+		}
+
+		task, err := c.Task(ctx, nil)
+		if err != nil {
+			log.Printf("[Health] unable to find task for %s: %s\n", c.ID(), err)
+			continue
+		}
+
+		ip, err := cninetwork.GetIPAddress(nil, task)
+		if err != nil {
+			log.Printf("[Health] unable to resolve IP for %s: %s\n", c.ID(), err)
+			continue
+		}
+
+		// Repopulate the resolver's in-memory state for every running
+		// container, not just ones with a health check -- it doesn't survive
+		// a faasd restart on its own, and InvokeResolver/update depend on it
+		// regardless of whether a health probe is configured.
+		setFunctionIP(c.ID(), ip)
+		setCurrentContainerID(canonicalServiceName(c.ID()), c.ID())
+
+		if _, _, _, ok := healthConfigFromLabels(labels); !ok {
+			continue
+		}
+
+		path, interval, timeout, _ := healthConfigFromLabels(labels)
+		container := c
+		onHealthy := checkpointOnFirstSuccess(client, container, labels)
+		healthRegistry.Start(c.ID(), ip, path, interval, timeout, onHealthy, func() {
+			log.Printf("[Health] %s failed %s, recreating task\n", container.ID(), path)
+
+			teardownCNINetwork(ctx, cni, task, container.ID())
+
+			if _, err := task.Delete(ctx, containerd.WithProcessKill); err != nil {
+				log.Printf("[Health] unable to kill unhealthy task %s: %s\n", container.ID(), err)
+			}
+
+			if err := createTask(ctx, client, container, cni); err != nil {
+				log.Printf("[Health] unable to recreate task for %s: %s\n", container.ID(), err)
+			}
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/namespaces"
+	"github.com/openfaas/faas-provider/types"
+	faasd "github.com/openfaas/faasd/pkg"
+)
+
+// MakeReadHandler returns a handler that lists deployed functions, reporting
+// back the Limits/Requests that were applied at deploy time.
+func MakeReadHandler(client *containerd.Client) func(w http.ResponseWriter, r *http.Request) {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := namespaces.WithNamespace(context.Background(), faasd.FunctionNamespace)
+
+		functions, err := listFunctions(ctx, client)
+		if err != nil {
+			log.Printf("[Read] error listing functions: %s\n", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		body, err := json.Marshal(functions)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}
+}
+
+func listFunctions(ctx context.Context, client *containerd.Client) ([]types.FunctionStatus, error) {
+	containers, err := client.Containers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	functions := []types.FunctionStatus{}
+	for _, c := range containers {
+		labels, err := c.Labels(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		limits, requests := resourcesFromLabels(labels)
+
+		functions = append(functions, types.FunctionStatus{
+			Name:     canonicalServiceName(c.ID()),
+			Limits:   limits,
+			Requests: requests,
+		})
+	}
+
+	return functions, nil
+}
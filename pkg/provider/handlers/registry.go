@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/containerd/containerd/remotes"
+	"github.com/containerd/containerd/remotes/docker"
+	"github.com/docker/distribution/reference"
+)
+
+const registryAuthSecretPrefix = "registry-auth-"
+
+// resolverForImage builds a remotes.Resolver authenticated against the
+// registry host that the image reference belongs to, using a faasd-managed
+// secret named registry-auth-<host> containing a docker-style config.json.
+// If no such secret exists, it returns (nil, nil) so callers fall back to
+// the default, unauthenticated resolver.
+func resolverForImage(secretMountPath string, imgRef reference.Named) (remotes.Resolver, error) {
+	host := reference.Domain(imgRef)
+
+	secretPath := path.Join(secretMountPath, registryAuthSecretPath(host))
+	if _, err := os.Stat(secretPath); err != nil {
+		return nil, nil
+	}
+
+	config, err := ioutil.ReadFile(secretPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read registry credentials for %s: %s", host, err)
+	}
+
+	user, pass, err := parseDockerConfig(config, host)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse registry credentials for %s: %s", host, err)
+	}
+
+	authorizer := docker.NewDockerAuthorizer(docker.WithAuthCreds(func(string) (string, string, error) {
+		return user, pass, nil
+	}))
+
+	return docker.NewResolver(docker.ResolverOptions{
+		Hosts: docker.ConfigureDefaultRegistries(docker.WithAuthorizer(authorizer)),
+	}), nil
+}
+
+func registryAuthSecretPath(host string) string {
+	return registryAuthSecretPrefix + host
+}
+
+// dockerConfig mirrors the subset of ~/.docker/config.json that faasd cares
+// about: per-host basic auth credentials.
+type dockerConfig struct {
+	Auths map[string]struct {
+		Auth string `json:"auth"`
+	} `json:"auths"`
+}
+
+// parseDockerConfig extracts the username/password for host out of a
+// docker-style config.json blob.
+func parseDockerConfig(config []byte, host string) (user, pass string, err error) {
+	parsed := dockerConfig{}
+	if err := json.Unmarshal(config, &parsed); err != nil {
+		return "", "", err
+	}
+
+	entry, ok := parsed.Auths[host]
+	if !ok {
+		return "", "", fmt.Errorf("no credentials found for host: %s", host)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+	if err != nil {
+		return "", "", err
+	}
+
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("malformed auth entry for host: %s", host)
+	}
+
+	return parts[0], parts[1], nil
+}
+
+// MakeRegistryAuthHandler returns a handler that manages the registry-auth-<host>
+// secrets used by resolverForImage, so credentials can be provisioned through
+// the API rather than by shelling into the host.
+func MakeRegistryAuthHandler(secretMountPath string) func(w http.ResponseWriter, r *http.Request) {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		host := r.URL.Query().Get("host")
+		if host == "" {
+			http.Error(w, "expected a host query parameter", http.StatusBadRequest)
+			return
+		}
+
+		secretPath := path.Join(secretMountPath, registryAuthSecretPath(host))
+
+		switch r.Method {
+		case http.MethodPost:
+			if r.Body == nil {
+				http.Error(w, "expected a body", http.StatusBadRequest)
+				return
+			}
+			defer r.Body.Close()
+
+			config, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			if err := ioutil.WriteFile(secretPath, config, 0600); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+		case http.MethodDelete:
+			if err := os.Remove(secretPath); err != nil && !os.IsNotExist(err) {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
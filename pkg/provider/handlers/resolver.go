@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+)
+
+// functionIPs is the in-memory map of container ID to the CNI IP address of
+// the task running inside it. It is populated by createTask for every
+// container, keyed the same way containerd keys the container itself.
+var functionIPs sync.Map // map[string]net.IP
+
+func setFunctionIP(containerID string, ip net.IP) {
+	functionIPs.Store(containerID, ip)
+}
+
+func lookupFunctionIP(containerID string) (net.IP, bool) {
+	val, ok := functionIPs.Load(containerID)
+	if !ok {
+		return nil, false
+	}
+	return val.(net.IP), true
+}
+
+func deleteFunctionIP(containerID string) {
+	functionIPs.Delete(containerID)
+}
+
+// containerIDs maps a function's logical service name to whichever container
+// ID is currently live for it -- "<svc>" normally, or "<svc>-next" while that
+// is the promoted blue/green replacement. The update handler repoints this
+// atomically once a replacement passes its readiness probe, which is what
+// actually makes the swap visible to InvokeResolver and to a later
+// delete/update of the same function.
+var containerIDs sync.Map // map[string]string
+
+// currentContainerID returns the container ID presently serving service. A
+// function that has never been through an update resolves to its own name,
+// since that's the container ID deploy created it under.
+func currentContainerID(service string) string {
+	val, ok := containerIDs.Load(service)
+	if !ok {
+		return service
+	}
+	return val.(string)
+}
+
+func setCurrentContainerID(service, containerID string) {
+	containerIDs.Store(service, containerID)
+}
+
+func deleteCurrentContainerID(service string) {
+	containerIDs.Delete(service)
+}
+
+// InvokeResolver implements faas-provider/proxy.BaseURLResolver by mapping a
+// function name to whichever container is currently registered as serving it
+// in containerIDs, then to that container's IP in functionIPs. It should be
+// passed to proxy.NewHandlerFunc when wiring up the gateway-facing invoke
+// route, so that a blue/green swap (handlers.update) takes effect on the very
+// next request instead of needing a fresh CNI lookup to notice the change.
+type InvokeResolver struct{}
+
+// NewInvokeResolver returns a resolver backed by the shared containerIDs and
+// functionIPs maps.
+func NewInvokeResolver() *InvokeResolver {
+	return &InvokeResolver{}
+}
+
+// Resolve returns the base URL currently serving functionName.
+func (InvokeResolver) Resolve(functionName string) (url.URL, error) {
+	containerID := currentContainerID(functionName)
+
+	ip, ok := lookupFunctionIP(containerID)
+	if !ok {
+		return url.URL{}, fmt.Errorf("unable to resolve IP for %s", functionName)
+	}
+
+	resolved, err := url.Parse(fmt.Sprintf("http://%s:8080", ip.String()))
+	if err != nil {
+		return url.URL{}, err
+	}
+
+	return *resolved, nil
+}
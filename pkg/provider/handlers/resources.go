@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/oci"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/openfaas/faas-provider/types"
+)
+
+const (
+	// defaultCPUPeriod is the period (in microseconds) used to translate
+	// a fractional CPU count into a quota, matching the Docker/Moby default.
+	defaultCPUPeriod = uint64(100000)
+
+	cpuShareUnit = 1024
+
+	resourceLabelLimitsMemory   = "com.openfaas.resources.limits.memory"
+	resourceLabelLimitsCPU      = "com.openfaas.resources.limits.cpu"
+	resourceLabelRequestsMemory = "com.openfaas.resources.requests.memory"
+	resourceLabelRequestsCPU    = "com.openfaas.resources.requests.cpu"
+)
+
+// resourcesFromLabels reconstructs the Limits/Requests of a FunctionDeployment
+// from the labels applied by buildLabels, so that the read handler
+// (listFunctions) can echo back what was actually requested at deploy time.
+func resourcesFromLabels(labels map[string]string) (limits, requests *types.FunctionResources) {
+	if mem, cpu := labels[resourceLabelLimitsMemory], labels[resourceLabelLimitsCPU]; mem != "" || cpu != "" {
+		limits = &types.FunctionResources{Memory: mem, CPU: cpu}
+	}
+
+	if mem, cpu := labels[resourceLabelRequestsMemory], labels[resourceLabelRequestsCPU]; mem != "" || cpu != "" {
+		requests = &types.FunctionResources{Memory: mem, CPU: cpu}
+	}
+
+	return limits, requests
+}
+
+// buildResourceSpecOpts translates the Limits/Requests of a FunctionDeployment
+// into containerd OCI spec options. Limits.Memory becomes a hard memory
+// limit, Limits.CPU becomes a CFS quota/period pair, and Requests.CPU becomes
+// a CPU shares weighting so the scheduler has a soft reservation to work
+// with. Requests.Memory has no OCI equivalent that's safe to apply here: the
+// obvious mapping, cgroup memsw (memory+swap), must be >= the memory limit
+// or runc refuses to start the container, and a request is normally smaller
+// than its limit.
+func buildResourceSpecOpts(limits, requests *types.FunctionResources) ([]oci.SpecOpts, error) {
+	opts := []oci.SpecOpts{}
+
+	if limits != nil {
+		if limits.Memory != "" {
+			mem, err := parseMemoryBytes(limits.Memory)
+			if err != nil {
+				return nil, fmt.Errorf("invalid limits.memory %q: %s", limits.Memory, err)
+			}
+			opts = append(opts, oci.WithMemoryLimit(uint64(mem)))
+		}
+
+		if limits.CPU != "" {
+			cpus, err := parseCPU(limits.CPU)
+			if err != nil {
+				return nil, fmt.Errorf("invalid limits.cpu %q: %s", limits.CPU, err)
+			}
+			quota := int64(cpus * float64(defaultCPUPeriod))
+			opts = append(opts, withCPUQuota(quota, defaultCPUPeriod))
+		}
+	}
+
+	if requests != nil {
+		if requests.CPU != "" {
+			cpus, err := parseCPU(requests.CPU)
+			if err != nil {
+				return nil, fmt.Errorf("invalid requests.cpu %q: %s", requests.CPU, err)
+			}
+			shares := uint64(cpus * cpuShareUnit)
+			opts = append(opts, oci.WithCPUShares(shares))
+		}
+	}
+
+	return opts, nil
+}
+
+// withCPUQuota sets a CFS quota/period pair on the spec's Linux resources,
+// creating the Resources/CPU blocks if they do not already exist.
+func withCPUQuota(quota int64, period uint64) oci.SpecOpts {
+	return func(_ context.Context, _ oci.Client, _ *containers.Container, s *specs.Spec) error {
+		if s.Linux == nil {
+			s.Linux = &specs.Linux{}
+		}
+		if s.Linux.Resources == nil {
+			s.Linux.Resources = &specs.LinuxResources{}
+		}
+		if s.Linux.Resources.CPU == nil {
+			s.Linux.Resources.CPU = &specs.LinuxCPU{}
+		}
+		s.Linux.Resources.CPU.Quota = &quota
+		s.Linux.Resources.CPU.Period = &period
+		return nil
+	}
+}
+
+// parseMemoryBytes parses a memory quantity such as "128Mi", "1Gi" or a plain
+// byte count into a number of bytes.
+func parseMemoryBytes(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(value, "Ki"):
+		multiplier = 1024
+		value = strings.TrimSuffix(value, "Ki")
+	case strings.HasSuffix(value, "Mi"):
+		multiplier = 1024 * 1024
+		value = strings.TrimSuffix(value, "Mi")
+	case strings.HasSuffix(value, "Gi"):
+		multiplier = 1024 * 1024 * 1024
+		value = strings.TrimSuffix(value, "Gi")
+	}
+
+	qty, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse quantity: %s", err)
+	}
+
+	return int64(qty * float64(multiplier)), nil
+}
+
+// parseCPU parses a CPU quantity expressed either as a fraction of a core
+// ("0.5") or in millicpus ("500m") into a number of cores.
+func parseCPU(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+
+	if strings.HasSuffix(value, "m") {
+		milli, err := strconv.ParseFloat(strings.TrimSuffix(value, "m"), 64)
+		if err != nil {
+			return 0, fmt.Errorf("unable to parse quantity: %s", err)
+		}
+		return milli / 1000, nil
+	}
+
+	cpus, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse quantity: %s", err)
+	}
+
+	return cpus, nil
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/openfaas/faas-provider/types"
+)
+
+func Test_ParseMemoryBytes(t *testing.T) {
+	cases := map[string]int64{
+		"128Mi": 128 * 1024 * 1024,
+		"1Gi":   1024 * 1024 * 1024,
+		"512Ki": 512 * 1024,
+		"100":   100,
+	}
+
+	for in, want := range cases {
+		got, err := parseMemoryBytes(in)
+		if err != nil {
+			t.Fatalf("want: no error for %s, got: %v", in, err)
+		}
+		if got != want {
+			t.Errorf("want: %d for %s, got: %d", want, in, got)
+		}
+	}
+}
+
+func Test_ParseCPU(t *testing.T) {
+	cases := map[string]float64{
+		"500m": 0.5,
+		"0.5":  0.5,
+		"2":    2,
+	}
+
+	for in, want := range cases {
+		got, err := parseCPU(in)
+		if err != nil {
+			t.Fatalf("want: no error for %s, got: %v", in, err)
+		}
+		if got != want {
+			t.Errorf("want: %f for %s, got: %f", want, in, got)
+		}
+	}
+}
+
+func Test_BuildResourceSpecOpts_Empty(t *testing.T) {
+	opts, err := buildResourceSpecOpts(nil, nil)
+	if err != nil {
+		t.Fatalf("want: no error, got: %v", err)
+	}
+	if len(opts) != 0 {
+		t.Errorf("want: 0 spec opts, got: %d", len(opts))
+	}
+}
+
+func Test_BuildResourceSpecOpts_WithLimits(t *testing.T) {
+	limits := &types.FunctionResources{Memory: "128Mi", CPU: "500m"}
+	opts, err := buildResourceSpecOpts(limits, nil)
+	if err != nil {
+		t.Fatalf("want: no error, got: %v", err)
+	}
+	if len(opts) != 2 {
+		t.Errorf("want: 2 spec opts, got: %d", len(opts))
+	}
+}
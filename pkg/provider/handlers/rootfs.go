@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+const (
+	readOnlyRootFSAnnotation = "com.openfaas.readonly_root_filesystem"
+	tmpfsSizeAnnotation      = "com.openfaas.tmpfs.size"
+	tmpfsPathsAnnotation     = "com.openfaas.tmpfs.paths"
+
+	defaultTmpfsSize = "64Mi"
+)
+
+// readOnlyRootFSEnabled reports whether the function opted into an
+// immutable, read-only root filesystem via its annotations.
+func readOnlyRootFSEnabled(annotations *map[string]string) bool {
+	if annotations == nil {
+		return false
+	}
+	return (*annotations)[readOnlyRootFSAnnotation] == "true"
+}
+
+// tmpfsMounts builds the tmpfs mounts needed to keep a read-only-rootfs
+// function writable in the paths it actually needs: /tmp and /run always,
+// plus any extra paths declared via com.openfaas.tmpfs.paths.
+func tmpfsMounts(annotations *map[string]string) []specs.Mount {
+	size := defaultTmpfsSize
+	if annotations != nil {
+		if val, ok := (*annotations)[tmpfsSizeAnnotation]; ok && val != "" {
+			size = val
+		}
+	}
+
+	paths := []string{"/tmp", "/run"}
+	if annotations != nil {
+		if val, ok := (*annotations)[tmpfsPathsAnnotation]; ok && val != "" {
+			for _, p := range strings.Split(val, ",") {
+				p = strings.TrimSpace(p)
+				if p != "" {
+					paths = append(paths, p)
+				}
+			}
+		}
+	}
+
+	mounts := make([]specs.Mount, 0, len(paths))
+	for _, p := range paths {
+		mounts = append(mounts, specs.Mount{
+			Destination: p,
+			Type:        "tmpfs",
+			Source:      "tmpfs",
+			Options:     []string{"nosuid", "nodev", fmt.Sprintf("size=%s", size)},
+		})
+	}
+
+	return mounts
+}
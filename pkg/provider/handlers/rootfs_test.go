@@ -0,0 +1,41 @@
+package handlers
+
+import "testing"
+
+func Test_ReadOnlyRootFSEnabled(t *testing.T) {
+	if readOnlyRootFSEnabled(nil) {
+		t.Errorf("want: false for nil annotations, got: true")
+	}
+
+	annotations := map[string]string{"com.openfaas.readonly_root_filesystem": "true"}
+	if !readOnlyRootFSEnabled(&annotations) {
+		t.Errorf("want: true, got: false")
+	}
+}
+
+func Test_TmpfsMounts_Defaults(t *testing.T) {
+	mounts := tmpfsMounts(nil)
+	if len(mounts) != 2 {
+		t.Fatalf("want: 2 default tmpfs mounts, got: %d", len(mounts))
+	}
+
+	if mounts[0].Destination != "/tmp" || mounts[1].Destination != "/run" {
+		t.Errorf("want: /tmp and /run, got: %s and %s", mounts[0].Destination, mounts[1].Destination)
+	}
+}
+
+func Test_TmpfsMounts_ExtraPaths(t *testing.T) {
+	annotations := map[string]string{
+		"com.openfaas.tmpfs.paths": "/var/scratch,/var/cache",
+		"com.openfaas.tmpfs.size":  "128Mi",
+	}
+
+	mounts := tmpfsMounts(&annotations)
+	if len(mounts) != 4 {
+		t.Fatalf("want: 4 tmpfs mounts, got: %d", len(mounts))
+	}
+
+	if mounts[3].Destination != "/var/cache" {
+		t.Errorf("want: /var/cache as last mount, got: %s", mounts[3].Destination)
+	}
+}
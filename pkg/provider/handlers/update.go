@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/namespaces"
+	gocni "github.com/containerd/go-cni"
+	"github.com/openfaas/faas-provider/types"
+	faasd "github.com/openfaas/faasd/pkg"
+	"github.com/openfaas/faasd/pkg/checkpoint"
+	"github.com/openfaas/faasd/pkg/health"
+)
+
+const (
+	nextContainerSuffix   = "-next"
+	readinessDeadline     = 30 * time.Second
+	defaultReadinessPath  = "/"
+	defaultReadinessProbe = 2 * time.Second
+)
+
+// deployMutexes serializes concurrent updates of the same function, so the
+// resolver swap below can't race with another update of the same service.
+// Only update takes this lock; the deploy and delete handlers do not.
+var deployMutexes sync.Map // map[string]*sync.Mutex
+
+func lockFor(name string) *sync.Mutex {
+	mutex, _ := deployMutexes.LoadOrStore(name, &sync.Mutex{})
+	return mutex.(*sync.Mutex)
+}
+
+// canonicalServiceName strips the "-next" suffix used by the blue/green swap
+// so that checkpoints and other function-keyed state survive an update,
+// which runs under a container ID of "<service>-next" until it is promoted.
+func canonicalServiceName(containerID string) string {
+	return strings.TrimSuffix(containerID, nextContainerSuffix)
+}
+
+// MakeUpdateHandler returns a handler that performs a blue/green redeploy of
+// an existing function: the new image is started alongside the old one, and
+// only promoted once it passes a readiness probe, so the gateway never routes
+// to a function that isn't there.
+func MakeUpdateHandler(client *containerd.Client, cni gocni.CNI, secretMountPath string, alwaysPull bool) func(w http.ResponseWriter, r *http.Request) {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		if r.Body == nil {
+			http.Error(w, "expected a body", http.StatusBadRequest)
+			return
+		}
+
+		defer r.Body.Close()
+
+		body, _ := ioutil.ReadAll(r.Body)
+		log.Printf("[Update] request: %s\n", string(body))
+
+		req := types.FunctionDeployment{}
+		err := json.Unmarshal(body, &req)
+		if err != nil {
+			log.Printf("[Update] - error parsing input: %s\n", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		name := req.Service
+		ctx := namespaces.WithNamespace(context.Background(), faasd.FunctionNamespace)
+
+		if updateErr := update(ctx, req, client, cni, secretMountPath, alwaysPull); updateErr != nil {
+			log.Printf("[Update] error updating %s, error: %s\n", name, updateErr)
+			http.Error(w, updateErr.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+}
+
+func update(ctx context.Context, req types.FunctionDeployment, client *containerd.Client, cni gocni.CNI, secretMountPath string, alwaysPull bool) error {
+	name := req.Service
+
+	mutex := lockFor(name)
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	currentName := currentContainerID(name)
+
+	existing, err := client.LoadContainer(ctx, currentName)
+	if err != nil {
+		return fmt.Errorf("unable to find function to update: %s, error: %s", name, err)
+	}
+
+	// Any checkpoint stored for this function captures the pre-update process
+	// state, keyed by its logical service name. It must be gone before the
+	// replacement task is created, or createTask will happily restore the old
+	// code under the new image.
+	if err := checkpoint.Delete(ctx, client, name); err != nil {
+		log.Printf("[Update] unable to remove stale checkpoint for %s: %s\n", name, err)
+	}
+
+	// containerd container IDs are immutable, so the replacement is built
+	// under a second, alternate ID rather than a throwaway one -- that same
+	// container stays live once promoted, instead of being cold-started
+	// again under the canonical name.
+	nextName := name + nextContainerSuffix
+	if currentName == nextName {
+		nextName = name
+	}
+
+	nextReq := req
+	nextReq.Service = nextName
+
+	if err := deploy(ctx, nextReq, client, cni, secretMountPath, alwaysPull); err != nil {
+		return fmt.Errorf("unable to start replacement container for %s: %s", name, err)
+	}
+
+	nextContainer, loadErr := client.LoadContainer(ctx, nextName)
+	if loadErr != nil {
+		return fmt.Errorf("unable to load replacement container for %s: %s", name, loadErr)
+	}
+
+	if err := awaitReady(ctx, nextContainer); err != nil {
+		log.Printf("[Update] replacement for %s failed readiness, rolling back: %s\n", name, err)
+		_ = removeContainer(ctx, nextContainer)
+		return fmt.Errorf("replacement container for %s failed readiness check: %s", name, err)
+	}
+
+	// The replacement is already warm and has proven itself healthy --
+	// repoint the resolver at it instead of cold-starting yet another task
+	// under the canonical name. The pointer swap can't fail, so the old
+	// container is only ever torn down after the service is already being
+	// served by the replacement; a failure removing it just leaks a
+	// container instead of the function.
+	setCurrentContainerID(name, nextName)
+
+	if err := removeContainer(ctx, existing); err != nil {
+		log.Printf("[Update] promoted %s to %s but failed to remove previous container %s: %s\n", name, nextName, currentName, err)
+	}
+
+	return nil
+}
+
+// awaitReady blocks until the replacement container's task responds
+// successfully to its readiness probe, or readinessDeadline elapses.
+func awaitReady(ctx context.Context, container containerd.Container) error {
+	labels, err := container.Labels(ctx)
+	if err != nil {
+		return err
+	}
+
+	ip, ok := lookupFunctionIP(container.ID())
+	if !ok {
+		return fmt.Errorf("no IP recorded for %s", container.ID())
+	}
+
+	path, _, timeout, ok := healthConfigFromLabels(labels)
+	if !ok {
+		path = defaultReadinessPath
+		timeout = defaultReadinessProbe
+	}
+
+	return health.WaitForReady(ip, path, timeout, readinessDeadline)
+}
+
+// removeContainer stops the container's task, if running, and deletes the
+// container along with its snapshot.
+func removeContainer(ctx context.Context, container containerd.Container) error {
+	name := container.ID()
+
+	healthRegistry.Stop(name)
+	deleteFunctionIP(name)
+
+	task, err := container.Task(ctx, nil)
+	if err == nil {
+		_, _ = task.Delete(ctx, containerd.WithProcessKill)
+	}
+
+	return container.Delete(ctx, containerd.WithSnapshotCleanup)
+}
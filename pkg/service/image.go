@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/remotes"
+)
+
+// PrepareImage pulls the given image, unless it is already present and
+// pullAlways is false. An optional resolver may be supplied to authenticate
+// against a private registry; if nil, the default resolver is used.
+func PrepareImage(ctx context.Context, client *containerd.Client, imageName, snapshotter string, pullAlways bool, resolver remotes.Resolver) (containerd.Image, error) {
+	opts := []containerd.RemoteOpt{
+		containerd.WithPullUnpack,
+		containerd.WithPullSnapshotter(snapshotter),
+	}
+
+	if resolver != nil {
+		opts = append(opts, containerd.WithResolver(resolver))
+	}
+
+	if !pullAlways {
+		if image, err := client.GetImage(ctx, imageName); err == nil {
+			return image, nil
+		}
+	}
+
+	log.Printf("Pulling: %s\n", imageName)
+	return client.Pull(ctx, imageName, opts...)
+}